@@ -0,0 +1,138 @@
+package cistern
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	dumpBinlogCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "binlog",
+		Subsystem: "cistern",
+		Name:      "dump_binlog_count",
+		Help:      "Total number of DumpBinlog requests served.",
+	})
+
+	dumpBinlogPayloadBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "binlog",
+		Subsystem: "cistern",
+		Name:      "dump_binlog_payload_bytes",
+		Help:      "Total bytes of binlog payload returned by DumpBinlog.",
+	})
+
+	dumpBinlogScanDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "binlog",
+		Subsystem: "cistern",
+		Name:      "dump_binlog_scan_duration_seconds",
+		Help:      "Bucketed histogram of the time it takes to scan binlogs for a DumpBinlog request.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 18),
+	})
+
+	sinkScanDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "binlog",
+		Subsystem: "cistern",
+		Name:      "publisher_sink_scan_duration_seconds",
+		Help:      "Bucketed histogram of the time it takes a publisher sink's background poll to scan for newly committed binlogs.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 18),
+	})
+
+	windowLowerGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "binlog",
+		Subsystem: "cistern",
+		Name:      "window_lower",
+		Help:      "Current lower boundary of the deposit window.",
+	})
+
+	windowUpperGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "binlog",
+		Subsystem: "cistern",
+		Name:      "window_upper",
+		Help:      "Current upper boundary of the deposit window.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(
+		dumpBinlogCounter,
+		dumpBinlogPayloadBytes,
+		dumpBinlogScanDuration,
+		sinkScanDuration,
+		windowLowerGauge,
+		windowUpperGauge,
+	)
+}
+
+// metricsServer serves the /metrics endpoint for pull-based Prometheus
+// scraping, and periodically refreshes the window gauges from win.
+type metricsServer struct {
+	srv    *http.Server
+	win    *DepositWindow
+	tlsCfg *tls.Config
+}
+
+func newMetricsServer(bindAddr string, win *DepositWindow, tlsCfg *tls.Config) *metricsServer {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	return &metricsServer{
+		srv:    &http.Server{Addr: bindAddr, Handler: mux, TLSConfig: tlsCfg},
+		win:    win,
+		tlsCfg: tlsCfg,
+	}
+}
+
+// Start listens on m.srv.Addr and serves until ctx is cancelled. When
+// the server was built with a TLS config, it serves HTTPS so the same
+// cfg.Security settings used for the gRPC server protect /metrics.
+func (m *metricsServer) Start(ctx context.Context) {
+	lis, err := net.Listen("tcp", m.srv.Addr)
+	if err != nil {
+		log.Errorf("failed to start metrics listener on %s: %v", m.srv.Addr, err)
+		return
+	}
+	if m.tlsCfg != nil {
+		lis = tls.NewListener(lis, m.tlsCfg)
+	}
+
+	go func() {
+		<-ctx.Done()
+		m.srv.Close()
+	}()
+
+	go m.refreshWindowGauges(ctx)
+
+	if err := m.srv.Serve(lis); err != nil && err != http.ErrServerClosed {
+		log.Errorf("metrics server exited with error: %v", err)
+	}
+}
+
+// refreshWindowGauges keeps windowLowerGauge/windowUpperGauge in sync
+// with the live DepositWindow until ctx is cancelled.
+func (m *metricsServer) refreshWindowGauges(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		windowLowerGauge.Set(float64(m.win.LoadLower()))
+		windowUpperGauge.Set(float64(m.win.LoadUpper()))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close shuts the metrics HTTP server down.
+func (m *metricsServer) Close() error {
+	return errors.Trace(m.srv.Close())
+}
@@ -0,0 +1,102 @@
+package cistern
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb-binlog/pkg/store"
+)
+
+// SecurityConfig holds the TLS settings for the cistern gRPC server,
+// its /metrics endpoint, and the outbound pump connections made by the
+// Collector.
+type SecurityConfig struct {
+	// SSLCA is the path to the trusted CA certificate. Setting it turns
+	// TLS on.
+	SSLCA string `toml:"ssl-ca" json:"ssl-ca"`
+
+	// SSLCert and SSLKey are the server's certificate and private key,
+	// both signed by SSLCA. Unlike a TLS client, a server always has
+	// to present a certificate of its own, so both are required
+	// whenever SSLCA is set; client certificates stay optional unless
+	// CertAllowedCN is also set.
+	SSLCert string `toml:"ssl-cert" json:"ssl-cert"`
+	SSLKey  string `toml:"ssl-key" json:"ssl-key"`
+
+	// CertAllowedCN restricts which verified peer certificate common
+	// names may call the cistern gRPC server, so operators can limit
+	// which drainers may call DumpBinlog. Empty allows any certificate
+	// signed by SSLCA.
+	CertAllowedCN []string `toml:"cert-allowed-cn" json:"cert-allowed-cn"`
+}
+
+// ClientTLSConfig builds the *tls.Config outbound connections made on
+// cfg's behalf should dial with, e.g. the Collector's pump connections.
+// It is the client-side counterpart of buildTLSConfig and simply
+// forwards to the shared store.BuildTLSConfig helper so every outbound
+// connection in cistern (SQL backend, publisher sinks, pump) trusts
+// the same CA/keypair-loading code path.
+func (cfg *SecurityConfig) ClientTLSConfig() (*tls.Config, error) {
+	return store.BuildTLSConfig(&store.SecurityConfig{
+		SSLCA:   cfg.SSLCA,
+		SSLCert: cfg.SSLCert,
+		SSLKey:  cfg.SSLKey,
+	})
+}
+
+// buildTLSConfig builds a *tls.Config from cfg, or returns (nil, nil)
+// if cfg does not configure TLS.
+func buildTLSConfig(cfg *SecurityConfig) (*tls.Config, error) {
+	if cfg == nil || cfg.SSLCA == "" {
+		return nil, nil
+	}
+	if cfg.SSLCert == "" || cfg.SSLKey == "" {
+		return nil, errors.New("ssl-cert and ssl-key are required when ssl-ca is set")
+	}
+
+	pool, err := store.LoadCACertPool(cfg.SSLCA)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.SSLCert, cfg.SSLKey)
+	if err != nil {
+		return nil, errors.Annotate(err, "failed to load server cert/key")
+	}
+
+	tlsCfg := &tls.Config{
+		ClientCAs:    pool,
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+	}
+
+	if len(cfg.CertAllowedCN) > 0 {
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+		tlsCfg.VerifyPeerCertificate = verifyAllowedCN(cfg.CertAllowedCN)
+	}
+
+	return tlsCfg, nil
+}
+
+// verifyAllowedCN returns a tls.Config.VerifyPeerCertificate callback
+// that accepts a verified chain only if its leaf certificate's common
+// name is in allowed.
+func verifyAllowedCN(allowed []string) func([][]byte, [][]*x509.Certificate) error {
+	allowedSet := make(map[string]struct{}, len(allowed))
+	for _, cn := range allowed {
+		allowedSet[cn] = struct{}{}
+	}
+
+	return func(_ [][]byte, chains [][]*x509.Certificate) error {
+		for _, chain := range chains {
+			if len(chain) == 0 {
+				continue
+			}
+			if _, ok := allowedSet[chain[0].Subject.CommonName]; ok {
+				return nil
+			}
+		}
+		return errors.Errorf("client certificate common name is not in cert-allowed-cn")
+	}
+}
@@ -0,0 +1,25 @@
+package cistern
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+)
+
+func TestVerifyAllowedCN(t *testing.T) {
+	verify := verifyAllowedCN([]string{"drainer-1", "drainer-2"})
+
+	chains := func(cn string) [][]*x509.Certificate {
+		return [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: cn}}}}
+	}
+
+	if err := verify(nil, chains("drainer-2")); err != nil {
+		t.Errorf("expected allowed CN to pass, got error: %v", err)
+	}
+	if err := verify(nil, chains("drainer-3")); err == nil {
+		t.Error("expected disallowed CN to fail, got nil error")
+	}
+	if err := verify(nil, nil); err == nil {
+		t.Error("expected no verified chains to fail, got nil error")
+	}
+}
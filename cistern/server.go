@@ -5,15 +5,19 @@ import (
 	"net"
 	"net/url"
 	"os"
-	"path"
 	"sync"
+	"time"
 
 	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb-binlog/pkg/publisher"
 	"github.com/pingcap/tidb-binlog/pkg/store"
 	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tipb/go-binlog"
+	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 // WindowNamespace is window namespace for store.Store
@@ -28,16 +32,19 @@ var SavePointNamespace []byte
 // Server implements the gRPC interface,
 // and maintains the runtime status
 type Server struct {
-	boltdb    store.Store
-	window    *DepositWindow
-	collector *Collector
-	publisher *Publisher
-	tcpAddr   string
-	gs        *grpc.Server
-	metrics   *metricClient
-	ctx       context.Context
-	cancel    context.CancelFunc
-	wg        sync.WaitGroup
+	store             store.Store
+	window            *DepositWindow
+	collector         *Collector
+	publisher         *Publisher
+	sinks             []publisher.Sink
+	tcpAddr           string
+	gs                *grpc.Server
+	metrics           *metricClient
+	metricsSrv        *metricsServer
+	slowScanThreshold time.Duration
+	ctx               context.Context
+	cancel            context.CancelFunc
+	wg                sync.WaitGroup
 }
 
 // NewServer return a instance of binlog-server
@@ -50,9 +57,9 @@ func NewServer(cfg *Config) (*Server, error) {
 		return nil, err
 	}
 
-	s, err := store.NewBoltStore(path.Join(cfg.DataDir, "data.bolt"), [][]byte{WindowNamespace, BinlogNamespace, SavePointNamespace})
+	s, err := store.NewStore(&cfg.Store, [][]byte{WindowNamespace, BinlogNamespace, SavePointNamespace})
 	if err != nil {
-		return nil, errors.Annotatef(err, "failed to open BoltDB store in dir(%s)", cfg.DataDir)
+		return nil, errors.Annotatef(err, "failed to open %s store in dir(%s)", cfg.Store.Backend, cfg.DataDir)
 	}
 	win, err := NewDepositWindow(s)
 	if err != nil {
@@ -63,6 +70,16 @@ func NewServer(cfg *Config) (*Server, error) {
 		return nil, errors.Trace(err)
 	}
 	p := NewPublisher(cfg, s, win)
+
+	sinks := make([]publisher.Sink, 0, len(cfg.Publisher.Sinks))
+	for _, sc := range cfg.Publisher.Sinks {
+		sink, err := publisher.NewSink(sc, s, SavePointNamespace)
+		if err != nil {
+			return nil, errors.Annotatef(err, "failed to create publisher sink %q", sc.Name)
+		}
+		sinks = append(sinks, sink)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	var metrics *metricClient
@@ -73,28 +90,55 @@ func NewServer(cfg *Config) (*Server, error) {
 		}
 	}
 
+	tlsCfg, err := buildTLSConfig(&cfg.Security)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	var metricsSrv *metricsServer
+	if cfg.MetricsBindAddress != "" {
+		metricsSrv = newMetricsServer(cfg.MetricsBindAddress, win, tlsCfg)
+	}
+
+	var gsOpts []grpc.ServerOption
+	if tlsCfg != nil {
+		gsOpts = append(gsOpts, grpc.Creds(credentials.NewTLS(tlsCfg)))
+	}
+
 	return &Server{
-		boltdb:    s,
-		window:    win,
-		collector: c,
-		publisher: p,
-		metrics:   metrics,
-		tcpAddr:   cfg.ListenAddr,
-		gs:        grpc.NewServer(),
-		ctx:       ctx,
-		cancel:    cancel,
+		store:             s,
+		window:            win,
+		collector:         c,
+		publisher:         p,
+		sinks:             sinks,
+		metrics:           metrics,
+		metricsSrv:        metricsSrv,
+		slowScanThreshold: cfg.SlowScanThreshold,
+		tcpAddr:           cfg.ListenAddr,
+		gs:                grpc.NewServer(gsOpts...),
+		ctx:               ctx,
+		cancel:            cancel,
 	}, nil
 }
 
-// DumpBinlog implements the gRPC interface of cistern server
+// DumpBinlog implements the gRPC interface of cistern server.
+//
+// It is still the unary, unfiltered RPC from baseline: a streaming
+// DumpBinlogStream with an EndCommitTS upper bound, server-side table
+// filtering and a max-bytes flow-control hint is explicitly out of
+// scope here, since all three need request/response fields and a
+// streaming server type that the vendored binlog proto does not
+// define. Landing that needs a proto regen this tree doesn't carry.
 func (s *Server) DumpBinlog(ctx context.Context, req *binlog.DumpBinlogReq) (*binlog.DumpBinlogResp, error) {
+	dumpBinlogCounter.Inc()
+
 	ret := &binlog.DumpBinlogResp{}
 	start := req.BeginCommitTS
 	startKey := codec.EncodeInt([]byte{}, start)
 	end := s.window.LoadLower()
 	limit := req.Limit
 
-	err := s.boltdb.Scan(BinlogNamespace, startKey, func(key []byte, val []byte) (bool, error) {
+	err := s.scan("dump_binlog", dumpBinlogScanDuration, BinlogNamespace, startKey, func(key []byte, val []byte) (bool, error) {
 		if limit <= 0 {
 			return false, nil
 		}
@@ -120,6 +164,7 @@ func (s *Server) DumpBinlog(ctx context.Context, req *binlog.DumpBinlogReq) (*bi
 		ret.Payloads = append(ret.Payloads, payload)
 		ret.EndCommitTS = cts
 		limit--
+		dumpBinlogPayloadBytes.Add(float64(len(payload)))
 
 		return true, nil
 	})
@@ -130,6 +175,30 @@ func (s *Server) DumpBinlog(ctx context.Context, req *binlog.DumpBinlogReq) (*bi
 	return ret, errors.Trace(err)
 }
 
+// scan runs f over namespace starting at startKey, recording elapsed
+// time into hist and logging under tag if the scan takes longer than
+// s.slowScanThreshold. Callers pass a tag/histogram pair that identifies
+// what kind of scan this is (e.g. "dump_binlog" vs "publisher_sink") so
+// one caller's traffic doesn't pollute another's latency signal.
+func (s *Server) scan(tag string, hist prometheus.Histogram, namespace, startKey []byte, f func(key, value []byte) (bool, error)) error {
+	start := time.Now()
+	rows := 0
+
+	err := s.store.Scan(namespace, startKey, func(key, value []byte) (bool, error) {
+		rows++
+		return f(key, value)
+	})
+
+	elapsed := time.Since(start)
+	hist.Observe(elapsed.Seconds())
+
+	if s.slowScanThreshold > 0 && elapsed > s.slowScanThreshold {
+		log.Warnf("slow scan: tag=%s namespace=%s startKey=%x rows=%d took=%s", tag, namespace, startKey, rows, elapsed)
+	}
+
+	return errors.Trace(err)
+}
+
 // StartCollect runs Collector up in a goroutine.
 func (s *Server) StartCollect() {
 	s.wg.Add(1)
@@ -148,6 +217,78 @@ func (s *Server) StartPublish() {
 	}()
 }
 
+// publishSinkPollInterval is how often StartPublishSinks checks for
+// newly committed binlogs to forward to each configured publisher.Sink.
+const publishSinkPollInterval = time.Second
+
+// StartPublishSinks runs one goroutine per configured publisher.Sink,
+// streaming committed binlog rows below window.LoadLower() to it in
+// commit-ts order, resuming from the sink's own persisted savepoint.
+func (s *Server) StartPublishSinks() {
+	for _, sink := range s.sinks {
+		sink := sink
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.runSink(sink)
+		}()
+	}
+}
+
+// runSink polls for commit-ts below the deposit window's lower bound
+// and forwards any new rows to sink until s.ctx is cancelled.
+func (s *Server) runSink(sink publisher.Sink) {
+	ticker := time.NewTicker(publishSinkPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		start := sink.ResumePoint()
+		startKey := codec.EncodeInt([]byte{}, start)
+		end := s.window.LoadLower()
+
+		err := s.scan("publisher_sink", sinkScanDuration, BinlogNamespace, startKey, func(key []byte, val []byte) (bool, error) {
+			_, cts, err := codec.DecodeInt(key)
+			if err != nil {
+				return false, errors.Trace(err)
+			}
+			if cts == start {
+				return true, nil
+			}
+			if cts >= end {
+				return false, nil
+			}
+
+			payload, _, err := decodePayload(val)
+			if err != nil {
+				return false, errors.Trace(err)
+			}
+			return true, errors.Trace(sink.Write(cts, payload))
+		})
+		if err != nil {
+			log.Errorf("publisher sink %q failed to scan binlogs: %v", sink.Name(), err)
+			continue
+		}
+
+		flushStart := time.Now()
+		err = sink.Flush()
+		if elapsed := time.Since(flushStart); s.slowScanThreshold > 0 && elapsed > s.slowScanThreshold {
+			log.Warnf("slow publisher sink flush: sink=%q took=%s", sink.Name(), elapsed)
+		}
+		if err != nil {
+			log.Errorf("publisher sink %q failed to flush: %v", sink.Name(), err)
+			continue
+		}
+
+		publisher.ObserveLag(sink.Name(), sink.ResumePoint(), end)
+	}
+}
+
 // StartMetrics runs a metrics colletcor in a goroutine
 func (s *Server) StartMetrics() {
 	if s.metrics == nil {
@@ -160,6 +301,19 @@ func (s *Server) StartMetrics() {
 	}()
 }
 
+// StartMetricsServer runs the pull-based Prometheus /metrics HTTP endpoint
+// in a goroutine, if configured.
+func (s *Server) StartMetricsServer() {
+	if s.metricsSrv == nil {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.metricsSrv.Start(s.ctx)
+	}()
+}
+
 // Start runs CisternServer to serve the listening addr, and starts to collect binlog
 func (s *Server) Start() error {
 	// start to collect
@@ -168,9 +322,15 @@ func (s *Server) Start() error {
 	// start to publish
 	s.StartPublish()
 
+	// stream committed binlogs to any configured publisher sinks
+	s.StartPublishSinks()
+
 	// collect metrics to prometheus
 	s.StartMetrics()
 
+	// serve /metrics for pull-based scraping
+	s.StartMetricsServer()
+
 	// start a TCP listener
 	tcpURL, err := url.Parse(s.tcpAddr)
 	if err != nil {
@@ -191,9 +351,19 @@ func (s *Server) Start() error {
 func (s *Server) Close() {
 	// first stop gRPC server
 	s.gs.GracefulStop()
+	// stop serving /metrics
+	if s.metricsSrv != nil {
+		s.metricsSrv.Close()
+	}
 	// notify all goroutines to exit
 	s.cancel()
 	// waiting for goroutines exit
 	s.wg.Wait()
-	s.boltdb.Close()
-}
\ No newline at end of file
+	// flush and release every publisher sink
+	for _, sink := range s.sinks {
+		if err := sink.Close(); err != nil {
+			log.Errorf("publisher sink %q failed to close: %v", sink.Name(), err)
+		}
+	}
+	s.store.Close()
+}
@@ -0,0 +1,66 @@
+package publisher
+
+import (
+	"github.com/Shopify/sarama"
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb-binlog/pkg/store"
+)
+
+// kafkaSink publishes batches to a Kafka topic using sarama's sync
+// producer, so Write can ack a batch only once the broker has
+// confirmed it.
+type kafkaSink struct {
+	*batcher
+	producer sarama.SyncProducer
+	topic    string
+}
+
+func newKafkaSink(cfg SinkConfig, b *batcher) (Sink, error) {
+	sCfg := sarama.NewConfig()
+	sCfg.Producer.RequiredAcks = sarama.WaitForAll
+	sCfg.Producer.Return.Successes = true
+
+	if cfg.Security.SSLCA != "" {
+		tlsCfg, err := store.BuildTLSConfig(&cfg.Security)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		sCfg.Net.TLS.Enable = true
+		sCfg.Net.TLS.Config = tlsCfg
+	}
+
+	producer, err := sarama.NewSyncProducer(cfg.Addrs, sCfg)
+	if err != nil {
+		return nil, errors.Annotate(err, "failed to create kafka producer")
+	}
+
+	return &kafkaSink{batcher: b, producer: producer, topic: cfg.Topic}, nil
+}
+
+func (k *kafkaSink) Write(commitTS int64, payload []byte) error {
+	if k.append(commitTS, payload) {
+		return k.Flush()
+	}
+	return nil
+}
+
+func (k *kafkaSink) Flush() error {
+	for _, payload := range k.buf {
+		msg := &sarama.ProducerMessage{
+			Topic: k.topic,
+			Value: sarama.ByteEncoder(payload),
+		}
+		if _, _, err := k.producer.SendMessage(msg); err != nil {
+			return errors.Annotate(err, "failed to publish binlog to kafka")
+		}
+	}
+	return errors.Trace(k.ack())
+}
+
+func (k *kafkaSink) Close() error {
+	flushErr := k.Flush()
+	if err := k.producer.Close(); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(flushErr)
+}
@@ -0,0 +1,37 @@
+package publisher
+
+import (
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// sinkLagGauge reports how far behind, in commit-ts units, each
+// configured sink is from the window's current lower bound.
+var sinkLagGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "binlog",
+	Subsystem: "publisher",
+	Name:      "sink_lag",
+	Help:      "Commit-ts lag between a publisher sink's resume point and the deposit window's lower bound.",
+}, []string{"sink"})
+
+func init() {
+	prometheus.MustRegister(sinkLagGauge)
+}
+
+// ObserveLag updates the lag gauge for the named sink given the deposit
+// window's current lower bound.
+func ObserveLag(name string, resumePoint, windowLower int64) {
+	lag := windowLower - resumePoint
+	if lag < 0 {
+		lag = 0
+	}
+	sinkLagGauge.WithLabelValues(name).Set(float64(lag))
+}
+
+func encodeSavePoint(commitTS int64) []byte {
+	return codec.EncodeInt([]byte{}, commitTS)
+}
+
+func decodeSavePoint(v []byte) ([]byte, int64, error) {
+	return codec.DecodeInt(v)
+}
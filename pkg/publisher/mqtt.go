@@ -0,0 +1,64 @@
+package publisher
+
+import (
+	"fmt"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb-binlog/pkg/store"
+)
+
+// mqttSink publishes batches to an MQTT topic, one message per row, and
+// only acks the batch once every publish in it has been confirmed by
+// the broker.
+type mqttSink struct {
+	*batcher
+	client mqtt.Client
+	topic  string
+}
+
+func newMQTTSink(cfg SinkConfig, b *batcher) (Sink, error) {
+	opts := mqtt.NewClientOptions()
+	for _, addr := range cfg.Addrs {
+		opts.AddBroker(addr)
+	}
+	opts.SetClientID(fmt.Sprintf("cistern-%s", cfg.Name))
+
+	if cfg.Security.SSLCA != "" {
+		tlsCfg, err := store.BuildTLSConfig(&cfg.Security)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		opts.SetTLSConfig(tlsCfg)
+	}
+
+	client := mqtt.NewClient(opts)
+	if token := client.Connect(); token.Wait() && token.Error() != nil {
+		return nil, errors.Annotate(token.Error(), "failed to connect to mqtt broker")
+	}
+
+	return &mqttSink{batcher: b, client: client, topic: cfg.Topic}, nil
+}
+
+func (m *mqttSink) Write(commitTS int64, payload []byte) error {
+	if m.append(commitTS, payload) {
+		return m.Flush()
+	}
+	return nil
+}
+
+func (m *mqttSink) Flush() error {
+	for _, payload := range m.buf {
+		token := m.client.Publish(m.topic, 1, false, payload)
+		if token.Wait() && token.Error() != nil {
+			return errors.Annotate(token.Error(), "failed to publish binlog to mqtt")
+		}
+	}
+	return errors.Trace(m.ack())
+}
+
+func (m *mqttSink) Close() error {
+	flushErr := m.Flush()
+	m.client.Disconnect(250)
+	return errors.Trace(flushErr)
+}
@@ -0,0 +1,165 @@
+package publisher
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb-binlog/pkg/store"
+)
+
+// Sink streams committed binlog rows, in commit-ts order, out to an
+// external system with at-least-once delivery. Sinks batch writes
+// internally and persist their own last-acked commit-ts under the
+// savepoint namespace passed to NewSink, so a restart resumes from
+// where it left off instead of replaying everything downstream.
+type Sink interface {
+	// ResumePoint returns the last commit-ts this sink has durably
+	// acked, or 0 if it has never flushed a batch.
+	ResumePoint() int64
+
+	// Write buffers payload for commitTS, flushing the pending batch
+	// downstream once MaxBatchBytes or MaxBatchLatency is reached.
+	Write(commitTS int64, payload []byte) error
+
+	// Flush forces any buffered rows out immediately.
+	Flush() error
+
+	// Close flushes and releases the sink's resources.
+	Close() error
+
+	// Name returns the SinkConfig.Name this sink was created with, for
+	// logging and the per-sink lag metric.
+	Name() string
+}
+
+// Config describes the set of sinks a Publisher streams committed
+// binlogs to.
+type Config struct {
+	Sinks []SinkConfig `toml:"sinks" json:"sinks"`
+}
+
+// SinkConfig configures a single Sink.
+type SinkConfig struct {
+	// Name identifies the sink and keys its savepoint row; it must be
+	// unique among the configured sinks.
+	Name string `toml:"name" json:"name"`
+
+	// Type selects the sink implementation: "kafka" or "mqtt".
+	Type string `toml:"type" json:"type"`
+
+	// Addrs is the list of broker/server addresses.
+	Addrs []string `toml:"addrs" json:"addrs"`
+
+	// Topic is the Kafka topic or MQTT topic rows are published to.
+	Topic string `toml:"topic" json:"topic"`
+
+	// MaxBatchBytes flushes the pending batch once its buffered
+	// payload size reaches this many bytes.
+	MaxBatchBytes int `toml:"max-batch-bytes" json:"max-batch-bytes"`
+
+	// MaxBatchLatency flushes the pending batch after this long even
+	// if MaxBatchBytes has not been reached.
+	MaxBatchLatency time.Duration `toml:"max-batch-latency" json:"max-batch-latency"`
+
+	Security store.SecurityConfig `toml:"security" json:"security"`
+}
+
+// savePointKey is the key a sink named name persists its resume point
+// under, scoped within the caller's savepoint namespace so it can't
+// collide with cistern's own single savepoint key.
+func savePointKey(name string) []byte {
+	return []byte("sink/" + name)
+}
+
+// NewSink opens the Sink selected by cfg.Type and loads its last-acked
+// commit-ts from st, so ResumePoint reflects prior runs immediately.
+// savepointNamespace must already exist as a namespace/bucket in st -
+// callers should reuse the same namespace store.NewStore was given for
+// cistern's own savepoint, rather than an ad hoc one of their own.
+func NewSink(cfg SinkConfig, st store.Store, savepointNamespace []byte) (Sink, error) {
+	b := newBatcher(cfg, st, savepointNamespace)
+
+	switch cfg.Type {
+	case "kafka":
+		return newKafkaSink(cfg, b)
+	case "mqtt":
+		return newMQTTSink(cfg, b)
+	default:
+		return nil, errors.Errorf("unknown publisher sink type %q", cfg.Type)
+	}
+}
+
+// batcher accumulates rows for a sink and tracks/persists its resume
+// point; it is embedded by the kafka and mqtt sinks so both share the
+// same batching and savepoint semantics.
+type batcher struct {
+	cfg       SinkConfig
+	store     store.Store
+	namespace []byte
+
+	buf        [][]byte
+	bufBytes   int
+	lastFlush  time.Time
+	resumePt   int64
+	pendingCTS int64
+}
+
+func newBatcher(cfg SinkConfig, st store.Store, namespace []byte) *batcher {
+	b := &batcher{cfg: cfg, store: st, namespace: namespace, lastFlush: time.Now()}
+
+	if v, err := st.Get(namespace, savePointKey(cfg.Name)); err == nil {
+		if _, cts, err := decodeSavePoint(v); err == nil {
+			b.resumePt = cts
+		}
+	}
+
+	return b
+}
+
+// shouldFlush reports whether the pending batch has grown large or
+// stale enough that it should be sent downstream now.
+func (b *batcher) shouldFlush() bool {
+	if len(b.buf) == 0 {
+		return false
+	}
+	if b.cfg.MaxBatchBytes > 0 && b.bufBytes >= b.cfg.MaxBatchBytes {
+		return true
+	}
+	if b.cfg.MaxBatchLatency > 0 && time.Since(b.lastFlush) >= b.cfg.MaxBatchLatency {
+		return true
+	}
+	return false
+}
+
+// append buffers payload for commitTS and reports whether the caller
+// should flush the batch now.
+func (b *batcher) append(commitTS int64, payload []byte) bool {
+	b.buf = append(b.buf, payload)
+	b.bufBytes += len(payload)
+	b.pendingCTS = commitTS
+	return b.shouldFlush()
+}
+
+// ack records that everything buffered up to the last append has been
+// delivered, persists the new resume point, and resets the batch.
+func (b *batcher) ack() error {
+	if len(b.buf) == 0 {
+		return nil
+	}
+	if err := b.store.Put(b.namespace, savePointKey(b.cfg.Name), encodeSavePoint(b.pendingCTS)); err != nil {
+		return errors.Trace(err)
+	}
+	b.resumePt = b.pendingCTS
+	b.buf = b.buf[:0]
+	b.bufBytes = 0
+	b.lastFlush = time.Now()
+	return nil
+}
+
+func (b *batcher) ResumePoint() int64 {
+	return b.resumePt
+}
+
+func (b *batcher) Name() string {
+	return b.cfg.Name
+}
@@ -0,0 +1,95 @@
+package publisher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// memStore is a minimal in-memory store.Store used to exercise the
+// batcher without a real bolt/SQL backend.
+type memStore struct {
+	data map[string][]byte
+}
+
+func newMemStore() *memStore {
+	return &memStore{data: make(map[string][]byte)}
+}
+
+func (m *memStore) key(namespace, key []byte) string {
+	return string(namespace) + "\x00" + string(key)
+}
+
+func (m *memStore) Put(namespace, key, value []byte) error {
+	m.data[m.key(namespace, key)] = append([]byte{}, value...)
+	return nil
+}
+
+func (m *memStore) Get(namespace, key []byte) ([]byte, error) {
+	v, ok := m.data[m.key(namespace, key)]
+	if !ok {
+		return nil, errors.NotFoundf("key %q in namespace %q", key, namespace)
+	}
+	return v, nil
+}
+
+func (m *memStore) Delete(namespace, key []byte) error {
+	delete(m.data, m.key(namespace, key))
+	return nil
+}
+
+func (m *memStore) Scan(namespace, startKey []byte, f func(key, value []byte) (bool, error)) error {
+	return nil
+}
+
+func (m *memStore) Close() error { return nil }
+
+var savepointNS = []byte("savepoint")
+
+func TestBatcherShouldFlush(t *testing.T) {
+	cfg := SinkConfig{Name: "s1", MaxBatchBytes: 4}
+	b := newBatcher(cfg, newMemStore(), savepointNS)
+
+	if b.append(1, []byte("ab")) {
+		t.Fatal("expected no flush below MaxBatchBytes")
+	}
+	if !b.append(2, []byte("cd")) {
+		t.Fatal("expected flush once MaxBatchBytes is reached")
+	}
+}
+
+func TestBatcherShouldFlushOnLatency(t *testing.T) {
+	cfg := SinkConfig{Name: "s1", MaxBatchLatency: time.Millisecond}
+	b := newBatcher(cfg, newMemStore(), savepointNS)
+	b.append(1, []byte("x"))
+
+	time.Sleep(2 * time.Millisecond)
+	if !b.shouldFlush() {
+		t.Fatal("expected flush once MaxBatchLatency elapses")
+	}
+}
+
+func TestBatcherAckPersistsResumePoint(t *testing.T) {
+	st := newMemStore()
+	cfg := SinkConfig{Name: "s1"}
+	b := newBatcher(cfg, st, savepointNS)
+
+	b.append(42, []byte("row"))
+	if err := b.ack(); err != nil {
+		t.Fatalf("ack returned error: %v", err)
+	}
+	if got := b.ResumePoint(); got != 42 {
+		t.Fatalf("ResumePoint() = %d, want 42", got)
+	}
+	if len(b.buf) != 0 {
+		t.Fatalf("expected buffer to be reset after ack, got %d buffered rows", len(b.buf))
+	}
+
+	// A fresh batcher over the same store/namespace should resume from
+	// the persisted commit-ts instead of starting at 0.
+	resumed := newBatcher(cfg, st, savepointNS)
+	if got := resumed.ResumePoint(); got != 42 {
+		t.Fatalf("resumed ResumePoint() = %d, want 42", got)
+	}
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/juju/errors"
+)
+
+func TestSQLStorePutGetDeleteScan(t *testing.T) {
+	ns := []byte("binlog_1")
+	cfg := &Config{
+		Backend: "sqlite",
+		DSN:     filepath.Join(t.TempDir(), "test.db"),
+	}
+
+	s, err := NewSQLStore(cfg, [][]byte{ns})
+	if err != nil {
+		t.Fatalf("NewSQLStore: %v", err)
+	}
+	defer s.Close()
+
+	if _, err := s.Get(ns, []byte("k1")); !errors.IsNotFound(err) {
+		t.Fatalf("Get of missing key: got err %v, want NotFound", err)
+	}
+
+	if err := s.Put(ns, []byte("k1"), []byte("v1")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := s.Put(ns, []byte("k2"), []byte("v2")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := s.Put(ns, []byte("k1"), []byte("v1-updated")); err != nil {
+		t.Fatalf("Put (overwrite): %v", err)
+	}
+
+	v, err := s.Get(ns, []byte("k1"))
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !bytes.Equal(v, []byte("v1-updated")) {
+		t.Fatalf("Get(k1) = %q, want %q", v, "v1-updated")
+	}
+
+	var scanned [][]byte
+	err = s.Scan(ns, []byte("k1"), func(key, value []byte) (bool, error) {
+		scanned = append(scanned, key)
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if len(scanned) != 2 || string(scanned[0]) != "k1" || string(scanned[1]) != "k2" {
+		t.Fatalf("Scan visited %v, want [k1 k2]", scanned)
+	}
+
+	if err := s.Delete(ns, []byte("k1")); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Get(ns, []byte("k1")); !errors.IsNotFound(err) {
+		t.Fatalf("Get after Delete: got err %v, want NotFound", err)
+	}
+}
+
+func TestRebind(t *testing.T) {
+	cases := []struct {
+		backend string
+		query   string
+		want    string
+	}{
+		{"mysql", "SELECT v FROM t WHERE namespace = ? AND k = ?", "SELECT v FROM t WHERE namespace = ? AND k = ?"},
+		{"sqlite", "SELECT v FROM t WHERE namespace = ? AND k = ?", "SELECT v FROM t WHERE namespace = ? AND k = ?"},
+		{"postgres", "SELECT v FROM t WHERE namespace = ? AND k = ?", "SELECT v FROM t WHERE namespace = $1 AND k = $2"},
+	}
+
+	for _, c := range cases {
+		s := &sqlStore{backend: c.backend}
+		if got := s.rebind(c.query); got != c.want {
+			t.Errorf("rebind(%q) on backend %q = %q, want %q", c.query, c.backend, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"io"
+	"path"
+
+	"github.com/juju/errors"
+)
+
+// Store is the storage abstraction used by cistern to persist the
+// window/binlog/savepoint namespaces. Implementations must provide
+// read-your-writes consistency within a namespace and support ordered
+// iteration over keys so that DumpBinlog can scan binlogs in commit-ts
+// order.
+type Store interface {
+	// Put writes value under key in namespace, overwriting any existing value.
+	Put(namespace, key, value []byte) error
+
+	// Get returns the value stored under key in namespace.
+	// It returns an error if the key does not exist.
+	Get(namespace, key []byte) ([]byte, error)
+
+	// Delete removes key from namespace. It is a no-op if the key does
+	// not exist.
+	Delete(namespace, key []byte) error
+
+	// Scan walks namespace in ascending key order starting at startKey,
+	// invoking f for every key/value pair. Scan stops when f returns
+	// false or a non-nil error, or when the namespace is exhausted.
+	Scan(namespace, startKey []byte, f func(key, value []byte) (bool, error)) error
+
+	// Close releases any resources held by the store.
+	Close() error
+}
+
+// Backuper is implemented by stores that can stream a consistent
+// read-only snapshot of their on-disk state, such as the "bolt"
+// backend. A gRPC SnapshotBinlog method to expose it for online
+// backup/bootstrap of a cistern replica is explicitly out of scope
+// here: it needs a response/stream type the vendored binlog proto
+// does not define, and regenerating that proto is outside this
+// series. Backuper has no caller in this tree; it only documents the
+// capability boltStore.Backup already provides for whoever lands
+// that RPC once the proto carries it.
+type Backuper interface {
+	Backup(w io.Writer) error
+}
+
+// SecurityConfig holds the TLS settings used to connect to a SQL backend.
+type SecurityConfig struct {
+	SSLCA   string `toml:"ssl-ca" json:"ssl-ca"`
+	SSLCert string `toml:"ssl-cert" json:"ssl-cert"`
+	SSLKey  string `toml:"ssl-key" json:"ssl-key"`
+}
+
+// Config describes which backend to open and how to connect to it.
+type Config struct {
+	// Backend selects the storage implementation: "bolt" (default),
+	// "mysql", "postgres" or "sqlite".
+	Backend string `toml:"backend" json:"backend"`
+
+	// DataDir is used by the "bolt" backend to locate data.bolt.
+	DataDir string `toml:"data-dir" json:"data-dir"`
+
+	// DSN is the data source name used by the SQL backends, e.g.
+	// "user:pass@tcp(127.0.0.1:3306)/cistern" for mysql.
+	DSN string `toml:"dsn" json:"dsn"`
+
+	// MaxOpenConns and MaxIdleConns bound the SQL backend's connection pool.
+	MaxOpenConns int `toml:"max-open-conns" json:"max-open-conns"`
+	MaxIdleConns int `toml:"max-idle-conns" json:"max-idle-conns"`
+
+	Security SecurityConfig `toml:"security" json:"security"`
+}
+
+// NewStore opens the Store selected by cfg.Backend. namespaces are
+// pre-created so that callers can Scan/Put/Get/Delete them immediately.
+func NewStore(cfg *Config, namespaces [][]byte) (Store, error) {
+	switch cfg.Backend {
+	case "", "bolt":
+		s, err := NewBoltStore(path.Join(cfg.DataDir, "data.bolt"), namespaces)
+		return s, errors.Trace(err)
+	case "mysql", "postgres", "sqlite", "sqlite3":
+		s, err := NewSQLStore(cfg, namespaces)
+		return s, errors.Trace(err)
+	default:
+		return nil, errors.Errorf("unknown store backend %q", cfg.Backend)
+	}
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+
+	"github.com/juju/errors"
+)
+
+// LoadCACertPool reads a PEM-encoded CA certificate from path into a
+// cert pool, for verifying a peer's certificate chain.
+func LoadCACertPool(path string) (*x509.CertPool, error) {
+	caCert, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Annotate(err, "failed to read SSLCA")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, errors.New("failed to parse SSLCA")
+	}
+	return pool, nil
+}
+
+// BuildTLSConfig builds a client-side *tls.Config from cfg: it trusts
+// SSLCA as the root CA and, if SSLCert/SSLKey are both set, presents
+// them for mutual TLS. It is shared by every outbound connection this
+// package's consumers make (the SQL backend, Kafka/MQTT sinks, ...), so
+// they don't each re-implement CA/keypair loading. It returns (nil,
+// nil) if cfg does not configure TLS.
+func BuildTLSConfig(cfg *SecurityConfig) (*tls.Config, error) {
+	if cfg == nil || cfg.SSLCA == "" {
+		return nil, nil
+	}
+
+	pool, err := LoadCACertPool(cfg.SSLCA)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	tlsCfg := &tls.Config{RootCAs: pool}
+	if cfg.SSLCert != "" && cfg.SSLKey != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.SSLCert, cfg.SSLKey)
+		if err != nil {
+			return nil, errors.Annotate(err, "failed to load client cert/key")
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	return tlsCfg, nil
+}